@@ -0,0 +1,275 @@
+package litestream
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/litestream"
+	"github.com/klauspost/compress/zstd"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/temporalio/temporalite/internal/litestream/config"
+)
+
+var (
+	backupSuccessTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "litestream_backup_success_total",
+		Help: "Number of successful scheduled backup snapshots.",
+	})
+	backupFailureTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "litestream_backup_failure_total",
+		Help: "Number of failed scheduled backup snapshots.",
+	})
+	backupDurationSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "litestream_backup_duration_seconds",
+		Help: "Duration of successful scheduled backup snapshot runs, in seconds.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(backupSuccessTotal, backupFailureTotal, backupDurationSeconds)
+}
+
+// Backup periodically produces a full snapshot of a SQLite database and
+// uploads it to a configured destination, independently of Litestream's
+// continuous WAL replication. It satisfies BackupServer.
+type Backup struct {
+	dbPath       string
+	backupConfig *config.BackupConfig
+
+	running sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewBackup returns a Backup that snapshots the database at dbPath on the
+// schedule described by backupConfig.
+func NewBackup(backupConfig *config.BackupConfig, dbPath string) *Backup {
+	return &Backup{
+		dbPath:       dbPath,
+		backupConfig: backupConfig,
+	}
+}
+
+// Start begins the periodic backup loop in the background. Canceling ctx
+// stops the loop, the same as calling Stop.
+func (b *Backup) Start(ctx context.Context) error {
+	if err := b.backupConfig.Validate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	b.cancel = cancel
+	b.done = make(chan struct{})
+
+	go b.loop(ctx)
+	return nil
+}
+
+// Stop cancels any in-flight backup and waits for the loop to exit.
+func (b *Backup) Stop() {
+	if b.cancel == nil {
+		return
+	}
+	b.cancel()
+	<-b.done
+}
+
+func (b *Backup) loop(ctx context.Context) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(*b.backupConfig.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.runOnce(ctx)
+		}
+	}
+}
+
+// runOnce performs a single backup run, skipping it if a previous run is
+// still in progress rather than allowing runs to pile up.
+func (b *Backup) runOnce(ctx context.Context) {
+	if !b.running.TryLock() {
+		fmt.Println("skipping scheduled backup, previous run still in progress")
+		return
+	}
+	defer b.running.Unlock()
+
+	start := time.Now()
+	err := b.snapshot(ctx)
+	duration := time.Since(start)
+
+	if err != nil {
+		backupFailureTotal.Inc()
+		fmt.Printf("scheduled backup failed after %s: %v\n", duration, err)
+		return
+	}
+	backupDurationSeconds.Observe(duration.Seconds())
+	backupSuccessTotal.Inc()
+}
+
+// snapshot takes a standalone copy of the database via VACUUM INTO and
+// uploads it to the backup destination. This is deliberately independent
+// of litestream.DB/Replica: b.dbPath may already be managed by a
+// continuous-replication Server, and opening a second litestream.DB
+// against the same path here would race with that Server over the
+// shadow-WAL and generation state it owns.
+func (b *Backup) snapshot(ctx context.Context) error {
+	snapshotPath, err := vacuumInto(ctx, b.dbPath)
+	if err != nil {
+		return fmt.Errorf("snapshot db: %w", err)
+	}
+	defer os.Remove(snapshotPath)
+
+	uploadPath := snapshotPath
+	if b.backupConfig.Compress != "" {
+		uploadPath, err = compressFile(snapshotPath, b.backupConfig.Compress)
+		if err != nil {
+			return fmt.Errorf("compress snapshot: %w", err)
+		}
+		defer os.Remove(uploadPath)
+	}
+
+	client, err := config.NewReplicaClientFromConfig(b.backupConfig.Destination)
+	if err != nil {
+		return fmt.Errorf("init backup destination: %w", err)
+	}
+	// The destination client is rebuilt from scratch on every run, so any
+	// sftp key temp file it resolved is only needed for this run. Scoped
+	// to b.backupConfig.Destination so this never touches temp files
+	// belonging to an unrelated, long-lived continuous-replication client.
+	defer config.CleanupTempCredentialFiles(b.backupConfig.Destination)
+
+	if err := uploadSnapshot(ctx, client, uploadPath); err != nil {
+		return fmt.Errorf("upload snapshot: %w", err)
+	}
+
+	if n := b.backupConfig.RetentionCount; n > 0 {
+		if err := pruneSnapshots(ctx, client, n); err != nil {
+			return fmt.Errorf("enforce retention: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// vacuumInto produces a consistent, standalone copy of the SQLite database
+// at dbPath using VACUUM INTO and returns the path to the copy, which is a
+// new temp file owned by the caller. This only reads from dbPath; it does
+// not require or interact with any litestream.DB managing it.
+func vacuumInto(ctx context.Context, dbPath string) (string, error) {
+	f, err := ioutil.TempFile("", "temporalite-litestream-backup-*.db")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	snapshotPath := f.Name()
+	f.Close()
+	// VACUUM INTO refuses to write to a file that already exists.
+	if err := os.Remove(snapshotPath); err != nil {
+		return "", fmt.Errorf("remove temp file placeholder: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath+"?mode=ro")
+	if err != nil {
+		return "", fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", snapshotPath); err != nil {
+		os.Remove(snapshotPath)
+		return "", fmt.Errorf("vacuum into: %w", err)
+	}
+	return snapshotPath, nil
+}
+
+// compressFile compresses the file at path using algo ("gzip" or "zstd")
+// and returns the path to a new temp file holding the result, owned by the
+// caller.
+func compressFile(path, algo string) (string, error) {
+	suffix := map[string]string{"gzip": "*.db.gz", "zstd": "*.db.zst"}[algo]
+	if suffix == "" {
+		return "", fmt.Errorf("unknown compress algorithm: %q", algo)
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	out, err := ioutil.TempFile("", "temporalite-litestream-backup-"+suffix)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	var w io.WriteCloser
+	switch algo {
+	case "gzip":
+		w = gzip.NewWriter(out)
+	case "zstd":
+		if w, err = zstd.NewWriter(out); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := io.Copy(w, in); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return out.Name(), nil
+}
+
+// uploadSnapshot writes the file at snapshotPath to client as a new,
+// self-contained generation, independent of any generation a continuous
+// replica might be tracking for the same destination.
+func uploadSnapshot(ctx context.Context, client litestream.ReplicaClient, snapshotPath string) error {
+	f, err := os.Open(snapshotPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// ReplicaClient.Generations lists only 16-hex-character generation
+	// names; a fixed-width hex timestamp is such a name and also sorts
+	// oldest-first, which pruneSnapshots relies on.
+	generation := fmt.Sprintf("%016x", time.Now().UnixNano())
+	_, err = client.WriteSnapshot(ctx, generation, 0, f)
+	return err
+}
+
+// pruneSnapshots deletes the oldest generations at client beyond the most
+// recent keep generations.
+func pruneSnapshots(ctx context.Context, client litestream.ReplicaClient, keep int) error {
+	generations, err := client.Generations(ctx)
+	if err != nil {
+		return fmt.Errorf("list generations: %w", err)
+	}
+	if len(generations) <= keep {
+		return nil
+	}
+
+	// Generations() returns oldest-first; drop everything but the most
+	// recent keep generations.
+	for _, generation := range generations[:len(generations)-keep] {
+		if err := client.DeleteAll(ctx, generation); err != nil {
+			return fmt.Errorf("delete generation %s: %w", generation, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// compressionAlgorithms lists the values accepted by BackupConfig.Compress.
+var compressionAlgorithms = map[string]bool{
+	"":     true,
+	"gzip": true,
+	"zstd": true,
+}
+
+// BackupConfig configures periodic full-snapshot backups of a database,
+// independent of Litestream's continuous WAL replication.
+type BackupConfig struct {
+	// How often to take a snapshot.
+	Interval *time.Duration `yaml:"interval"`
+
+	// Cron schedule for snapshots, e.g. "0 * * * *". Cron schedules are not
+	// supported: Validate rejects any non-empty value, so snapshots are
+	// scheduled by Interval only.
+	Cron string `yaml:"cron"`
+
+	// Where to upload snapshots. Reuses ReplicaConfig so the same
+	// destinations and credential resolution available to continuous
+	// replication are available to backups.
+	Destination *ReplicaConfig `yaml:"destination"`
+
+	// Number of snapshots to retain at the destination; older snapshots
+	// are pruned after each successful upload. Zero disables pruning.
+	RetentionCount int `yaml:"retention-count"`
+
+	// Compression applied to the snapshot: "", "gzip", or "zstd".
+	Compress string `yaml:"compress"`
+}
+
+// Validate returns an error if the backup configuration is incomplete or
+// contradictory.
+func (c *BackupConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.Cron != "" {
+		return fmt.Errorf("backup: cron schedules are not yet supported, use interval")
+	}
+	if c.Interval == nil {
+		return fmt.Errorf("backup: interval required")
+	}
+	if *c.Interval <= 0 {
+		return fmt.Errorf("backup: interval must be positive")
+	}
+	if c.Destination == nil {
+		return fmt.Errorf("backup: destination required")
+	}
+	if err := c.Destination.Validate(); err != nil {
+		return fmt.Errorf("backup destination: %w", err)
+	}
+	if c.RetentionCount < 0 {
+		return fmt.Errorf("backup: retention-count cannot be negative")
+	}
+	if !compressionAlgorithms[c.Compress] {
+		return fmt.Errorf("backup: unknown compress algorithm: %q", c.Compress)
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// CredentialResolver resolves a credential reference into its literal
+// value. Implementations are registered under the URL scheme they handle
+// (e.g. "file", "env", "k8s") and are invoked whenever a ReplicaConfig
+// field looks like a reference rather than a plaintext value.
+type CredentialResolver interface {
+	// Scheme returns the URL scheme this resolver is registered for.
+	Scheme() string
+
+	// Resolve returns the value referenced by ref. ref is passed without
+	// its scheme prefix (e.g. "namespace/name#key" for "k8s://namespace/name#key").
+	Resolve(ref string) (string, error)
+}
+
+// credentialResolvers holds the resolvers available to resolveCredential,
+// keyed by scheme. Populated by RegisterCredentialResolver.
+var credentialResolvers = map[string]CredentialResolver{}
+
+// RegisterCredentialResolver makes r available for references using its
+// scheme. Intended to be called from an init() function, either in this
+// package or by callers wiring in additional providers at startup.
+func RegisterCredentialResolver(r CredentialResolver) {
+	credentialResolvers[r.Scheme()] = r
+}
+
+func init() {
+	RegisterCredentialResolver(&fileCredentialResolver{})
+	RegisterCredentialResolver(&envCredentialResolver{})
+	RegisterCredentialResolver(&k8sCredentialResolver{})
+}
+
+// resolveCredential returns the literal value for s. If s is a reference
+// (e.g. "file://...", "env://...", "k8s://...") it is resolved via the
+// registered CredentialResolver for its scheme; otherwise s is returned
+// unchanged, allowing plaintext values to keep working.
+func resolveCredential(s string) (string, error) {
+	if s == "" || !isURL(s) {
+		return s, nil
+	}
+
+	scheme, ref, ok := splitCredentialRef(s)
+	if !ok {
+		return s, nil
+	}
+
+	r, ok := credentialResolvers[scheme]
+	if !ok {
+		return "", fmt.Errorf("no credential resolver registered for scheme: %q", scheme)
+	}
+
+	v, err := r.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s credential: %w", scheme, err)
+	}
+	return v, nil
+}
+
+// tempCredentialFilesMu guards tempCredentialFiles.
+var tempCredentialFilesMu sync.Mutex
+
+// tempCredentialFiles tracks temp files written by resolveKeyPathCredential,
+// keyed by the *ReplicaConfig the client was built from, so
+// CleanupTempCredentialFiles can remove a single client's temp files once
+// they are no longer needed without disturbing any other client's, rather
+// than letting key material accumulate in the OS temp dir or clearing it
+// out from under an unrelated, still-running client.
+var tempCredentialFiles = map[*ReplicaConfig][]string{}
+
+// CleanupTempCredentialFiles removes any temporary files created by
+// resolveKeyPathCredential while building a client for c, and forgets them.
+// Callers that rebuild a replica client from c on a schedule (e.g. Backup)
+// should call this once that client is no longer needed. It has no effect
+// on temp files tracked under a different *ReplicaConfig.
+func CleanupTempCredentialFiles(c *ReplicaConfig) {
+	tempCredentialFilesMu.Lock()
+	files := tempCredentialFiles[c]
+	delete(tempCredentialFiles, c)
+	tempCredentialFilesMu.Unlock()
+
+	for _, path := range files {
+		os.Remove(path)
+	}
+}
+
+// resolveKeyPathCredential returns a filesystem path to an SSH private key
+// for use as sftp.ReplicaClient.KeyPath. If keyPath is a credential
+// reference (e.g. "k8s://...") its resolved contents are written to a
+// private temporary file and that file's path is returned, since the
+// underlying SFTP client reads the key directly from disk. The temp file is
+// tracked under c for later removal by CleanupTempCredentialFiles. A plain
+// path is returned unchanged.
+func resolveKeyPathCredential(c *ReplicaConfig, keyPath string) (string, error) {
+	if keyPath == "" || !isURL(keyPath) {
+		return keyPath, nil
+	}
+
+	contents, err := resolveCredential(keyPath)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := ioutil.TempFile("", "temporalite-litestream-sftp-key-*")
+	if err != nil {
+		return "", fmt.Errorf("create temp file for sftp key: %w", err)
+	}
+	defer f.Close()
+
+	if err := f.Chmod(0o600); err != nil {
+		return "", fmt.Errorf("chmod temp file for sftp key: %w", err)
+	}
+	if _, err := f.WriteString(contents); err != nil {
+		return "", fmt.Errorf("write temp file for sftp key: %w", err)
+	}
+
+	tempCredentialFilesMu.Lock()
+	tempCredentialFiles[c] = append(tempCredentialFiles[c], f.Name())
+	tempCredentialFilesMu.Unlock()
+
+	return f.Name(), nil
+}
+
+// checkCredentialRefReachable checks that ref's scheme is registered and,
+// where possible without opening a network connection, that the reference
+// itself is reachable: that a file:// path exists and an env:// variable is
+// set. It does not attempt to contact the Kubernetes API for k8s:// refs,
+// since that would require connecting; k8s:// refs are only checked for
+// well-formedness.
+func checkCredentialRefReachable(ref string) error {
+	scheme, rest, ok := splitCredentialRef(ref)
+	if !ok {
+		return nil
+	}
+
+	if _, ok := credentialResolvers[scheme]; !ok {
+		return fmt.Errorf("no credential resolver registered for scheme: %q", scheme)
+	}
+
+	switch scheme {
+	case "file":
+		if _, err := os.Stat(rest); err != nil {
+			return fmt.Errorf("file credential reference unreachable: %w", err)
+		}
+	case "env":
+		if _, ok := os.LookupEnv(rest); !ok {
+			return fmt.Errorf("env credential reference unreachable: environment variable not set: %s", rest)
+		}
+	case "k8s":
+		if _, _, _, err := parseK8sRef(rest); err != nil {
+			return fmt.Errorf("k8s credential reference invalid: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// splitCredentialRef splits s of the form "scheme://ref" into its scheme
+// and ref parts. ok is false if s has no "://" separator; it is true as
+// long as a scheme could be extracted, even if no resolver is registered
+// for it (callers distinguish that case by checking credentialResolvers).
+func splitCredentialRef(s string) (scheme, ref string, ok bool) {
+	i := strings.Index(s, "://")
+	if i < 0 {
+		return "", "", false
+	}
+	return s[:i], s[i+len("://"):], true
+}
+
+// fileCredentialResolver resolves "file://path" references by reading the
+// contents of the file at path, trimming a single trailing newline.
+type fileCredentialResolver struct{}
+
+func (*fileCredentialResolver) Scheme() string { return "file" }
+
+func (*fileCredentialResolver) Resolve(ref string) (string, error) {
+	buf, err := ioutil.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(buf), "\n"), nil
+}
+
+// envCredentialResolver resolves "env://NAME" references to the value of
+// the NAME environment variable.
+type envCredentialResolver struct{}
+
+func (*envCredentialResolver) Scheme() string { return "env" }
+
+func (*envCredentialResolver) Resolve(ref string) (string, error) {
+	v, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable not set: %s", ref)
+	}
+	return v, nil
+}
+
+// k8sCredentialResolver resolves "k8s://namespace/name#key" references to
+// the value of key in the data of the Secret called name in namespace, read
+// from the in-cluster Kubernetes API. This keeps credentials out of the
+// config file and the process environment, at the cost of requiring
+// Temporalite to run with a service account able to read the Secret.
+type k8sCredentialResolver struct{}
+
+func (*k8sCredentialResolver) Scheme() string { return "k8s" }
+
+func (*k8sCredentialResolver) Resolve(ref string) (string, error) {
+	namespace, name, key, err := parseK8sRef(ref)
+	if err != nil {
+		return "", err
+	}
+
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return "", fmt.Errorf("load in-cluster config: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return "", fmt.Errorf("build kubernetes client: %w", err)
+	}
+
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("get secret %s/%s: %w", namespace, name, err)
+	}
+
+	v, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in secret %s/%s", key, namespace, name)
+	}
+	return string(v), nil
+}
+
+// parseK8sRef splits ref of the form "namespace/name#key" into its parts.
+func parseK8sRef(ref string) (namespace, name, key string, err error) {
+	parts := strings.SplitN(ref, "#", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", "", fmt.Errorf("k8s credential ref must be of the form namespace/name#key: %s", ref)
+	}
+	nsName := strings.SplitN(parts[0], "/", 2)
+	if len(nsName) != 2 || nsName[0] == "" || nsName[1] == "" {
+		return "", "", "", fmt.Errorf("k8s credential ref must be of the form namespace/name#key: %s", ref)
+	}
+	return nsName[0], nsName[1], parts[1], nil
+}
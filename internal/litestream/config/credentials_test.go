@@ -0,0 +1,61 @@
+package config
+
+import "testing"
+
+func TestSplitCredentialRef(t *testing.T) {
+	tests := []struct {
+		name       string
+		s          string
+		wantScheme string
+		wantRef    string
+		wantOK     bool
+	}{
+		{"file ref", "file:///etc/secret", "file", "/etc/secret", true},
+		{"env ref", "env://MY_VAR", "env", "MY_VAR", true},
+		{"k8s ref", "k8s://ns/name#key", "k8s", "ns/name#key", true},
+		{"unregistered scheme still splits", "vault://secret/data", "vault", "secret/data", true},
+		{"no scheme separator", "plaintext-value", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme, ref, ok := splitCredentialRef(tt.s)
+			if scheme != tt.wantScheme || ref != tt.wantRef || ok != tt.wantOK {
+				t.Errorf("splitCredentialRef(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.s, scheme, ref, ok, tt.wantScheme, tt.wantRef, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseK8sRef(t *testing.T) {
+	tests := []struct {
+		name          string
+		ref           string
+		wantNamespace string
+		wantName      string
+		wantKey       string
+		wantErr       bool
+	}{
+		{"valid ref", "ns/name#key", "ns", "name", "key", false},
+		{"missing key separator", "ns/name", "", "", "", true},
+		{"empty key", "ns/name#", "", "", "", true},
+		{"missing namespace separator", "name#key", "", "", "", true},
+		{"empty namespace", "/name#key", "", "", "", true},
+		{"empty name", "ns/#key", "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace, name, key, err := parseK8sRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseK8sRef(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if namespace != tt.wantNamespace || name != tt.wantName || key != tt.wantKey {
+				t.Errorf("parseK8sRef(%q) = (%q, %q, %q), want (%q, %q, %q)",
+					tt.ref, namespace, name, key, tt.wantNamespace, tt.wantName, tt.wantKey)
+			}
+		})
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"github.com/benbjohnson/litestream/s3"
 	"github.com/benbjohnson/litestream/sftp"
 	"gopkg.in/yaml.v2"
+
+	"github.com/temporalio/temporalite/internal/litestream/config/options"
 )
 
 // Config represents a configuration file for the litestream daemon.
@@ -35,6 +37,89 @@ type Config struct {
 	// Global S3 settings
 	AccessKeyID     string `yaml:"access-key-id"`
 	SecretAccessKey string `yaml:"secret-access-key"`
+
+	// Default settings applied to every replica that does not set its own
+	// value, optionally scoped to a single replica type.
+	ReplicaDefaults ReplicaDefaults `yaml:"replica-defaults"`
+
+	// Periodic full-snapshot backup, run independently of the continuous
+	// WAL replication configured per DB above. Optional.
+	Backup *BackupConfig `yaml:"backup"`
+}
+
+// ReplicaDefaults holds settings that are applied to every replica config
+// that leaves the corresponding field unset. Settings nested under a
+// replica type (e.g. "s3") only apply to replicas of that type and take
+// precedence over the top-level defaults.
+type ReplicaDefaults struct {
+	Retention              *time.Duration `yaml:"retention"`
+	RetentionCheckInterval *time.Duration `yaml:"retention-check-interval"`
+	SyncInterval           *time.Duration `yaml:"sync-interval"`
+	SnapshotInterval       *time.Duration `yaml:"snapshot-interval"`
+	ValidationInterval     *time.Duration `yaml:"validation-interval"`
+
+	// S3 defaults
+	Region         string `yaml:"region"`
+	Endpoint       string `yaml:"endpoint"`
+	ForcePathStyle *bool  `yaml:"force-path-style"`
+
+	// ABS defaults
+	AccountName string `yaml:"account-name"`
+
+	// SFTP defaults
+	User    string `yaml:"user"`
+	KeyPath string `yaml:"key-path"`
+
+	// Per-replica-type overrides of the defaults above, keyed by replica
+	// type ("file", "s3", "gs", "abs", "sftp").
+	Types map[string]*ReplicaDefaults `yaml:"types"`
+}
+
+// forType returns the defaults scoped to typ, falling back to the
+// top-level defaults for any field left unset in the per-type block.
+func (d *ReplicaDefaults) forType(typ string) ReplicaDefaults {
+	merged := *d
+	merged.Types = nil
+
+	t, ok := d.Types[typ]
+	if !ok || t == nil {
+		return merged
+	}
+
+	if t.Retention != nil {
+		merged.Retention = t.Retention
+	}
+	if t.RetentionCheckInterval != nil {
+		merged.RetentionCheckInterval = t.RetentionCheckInterval
+	}
+	if t.SyncInterval != nil {
+		merged.SyncInterval = t.SyncInterval
+	}
+	if t.SnapshotInterval != nil {
+		merged.SnapshotInterval = t.SnapshotInterval
+	}
+	if t.ValidationInterval != nil {
+		merged.ValidationInterval = t.ValidationInterval
+	}
+	if t.Region != "" {
+		merged.Region = t.Region
+	}
+	if t.Endpoint != "" {
+		merged.Endpoint = t.Endpoint
+	}
+	if t.ForcePathStyle != nil {
+		merged.ForcePathStyle = t.ForcePathStyle
+	}
+	if t.AccountName != "" {
+		merged.AccountName = t.AccountName
+	}
+	if t.User != "" {
+		merged.User = t.User
+	}
+	if t.KeyPath != "" {
+		merged.KeyPath = t.KeyPath
+	}
+	return merged
 }
 
 // DBConfig represents the configuration for a single database.
@@ -79,6 +164,23 @@ type ReplicaConfig struct {
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
 	KeyPath  string `yaml:"key-path"`
+
+	// Advanced, backend-specific tuning knobs, e.g. "endpoint-suffix" for
+	// abs. Parsed via options.Options.ParseFlag for an eventual repeated
+	// `--replica-option key=value` CLI flag. Backends with no advanced
+	// knobs yet reject any key.
+	Options options.Options `yaml:"options"`
+}
+
+// replicaOptionKeys lists the option keys each replica backend accepts via
+// ReplicaConfig.Options. Keys outside a backend's list are rejected when
+// the replica client is built.
+var replicaOptionKeys = map[string][]string{
+	"file": nil,
+	"s3":   nil,
+	"gs":   nil,
+	"abs":  {"endpoint-suffix"},
+	"sftp": nil,
 }
 
 // ReplicaType returns the type based on the type field or extracted from the URL.
@@ -105,18 +207,68 @@ func DefaultConfigPath() string {
 	return "/etc/litestream.yml"
 }
 
-// propagateGlobalSettings copies global S3 settings to replica configs.
+// propagateGlobalSettings copies global S3 settings and replica defaults to
+// every replica config, including the backup destination.
 func (c *Config) propagateGlobalSettings() {
 	for _, dbc := range c.DBs {
 		for _, rc := range dbc.Replicas {
-			if rc.AccessKeyID == "" {
-				rc.AccessKeyID = c.AccessKeyID
-			}
-			if rc.SecretAccessKey == "" {
-				rc.SecretAccessKey = c.SecretAccessKey
-			}
+			c.propagateToReplica(rc)
 		}
 	}
+
+	if c.Backup != nil {
+		c.propagateToReplica(c.Backup.Destination)
+	}
+}
+
+// propagateToReplica copies global S3 settings and replica defaults into
+// rc wherever rc leaves a field unset.
+func (c *Config) propagateToReplica(rc *ReplicaConfig) {
+	if rc == nil {
+		return
+	}
+
+	if rc.AccessKeyID == "" {
+		rc.AccessKeyID = c.AccessKeyID
+	}
+	if rc.SecretAccessKey == "" {
+		rc.SecretAccessKey = c.SecretAccessKey
+	}
+
+	d := c.ReplicaDefaults.forType(rc.ReplicaType())
+	if rc.Retention == nil {
+		rc.Retention = d.Retention
+	}
+	if rc.RetentionCheckInterval == nil {
+		rc.RetentionCheckInterval = d.RetentionCheckInterval
+	}
+	if rc.SyncInterval == nil {
+		rc.SyncInterval = d.SyncInterval
+	}
+	if rc.SnapshotInterval == nil {
+		rc.SnapshotInterval = d.SnapshotInterval
+	}
+	if rc.ValidationInterval == nil {
+		rc.ValidationInterval = d.ValidationInterval
+	}
+	if rc.Region == "" {
+		rc.Region = d.Region
+	}
+	if rc.Endpoint == "" {
+		rc.Endpoint = d.Endpoint
+	}
+	if rc.ForcePathStyle == nil {
+		rc.ForcePathStyle = d.ForcePathStyle
+	}
+	if rc.AccountName == "" {
+		rc.AccountName = d.AccountName
+	}
+	if rc.User == "" {
+		rc.User = d.User
+	}
+	if rc.KeyPath == "" {
+		rc.KeyPath = d.KeyPath
+	}
 }
 
 // ReadConfigFile unmarshals config from filename. Expands path if needed.
@@ -146,39 +298,37 @@ func ReadConfigFile(filename string, expandEnv bool) (config Config, err error)
 	return config, nil
 }
 
-// NewReplicaFromConfig instantiates a replica for a DB based on a config.
-func NewReplicaFromConfig(c *ReplicaConfig, db *litestream.DB) (_ *litestream.Replica, err error) {
+// NewReplicaClientFromConfig instantiates a replica client based on a
+// config, without attaching it to a DB. Useful for anything that talks to
+// a replica destination directly, such as the backup subsystem.
+func NewReplicaClientFromConfig(c *ReplicaConfig) (litestream.ReplicaClient, error) {
 	// Ensure user did not specify URL in path.
 	if isURL(c.Path) {
 		return nil, fmt.Errorf("replica path cannot be a url, please use the 'url' field instead: %s", c.Path)
 	}
 
-	// Build and set client on replica.
-	var client litestream.ReplicaClient
 	switch typ := c.ReplicaType(); typ {
 	case "file":
-		if client, err = newFileReplicaClientFromConfig(c); err != nil {
-			return nil, err
-		}
+		return newFileReplicaClientFromConfig(c)
 	case "s3":
-		if client, err = newS3ReplicaClientFromConfig(c); err != nil {
-			return nil, err
-		}
+		return newS3ReplicaClientFromConfig(c)
 	case "gs":
-		if client, err = newGSReplicaClientFromConfig(c); err != nil {
-			return nil, err
-		}
+		return newGSReplicaClientFromConfig(c)
 	case "abs":
-		if client, err = newABSReplicaClientFromConfig(c); err != nil {
-			return nil, err
-		}
+		return newABSReplicaClientFromConfig(c)
 	case "sftp":
-		if client, err = newSFTPReplicaClientFromConfig(c); err != nil {
-			return nil, err
-		}
+		return newSFTPReplicaClientFromConfig(c)
 	default:
 		return nil, fmt.Errorf("unknown replica type in config: %q", typ)
 	}
+}
+
+// NewReplicaFromConfig instantiates a replica for a DB based on a config.
+func NewReplicaFromConfig(c *ReplicaConfig, db *litestream.DB) (_ *litestream.Replica, err error) {
+	client, err := NewReplicaClientFromConfig(c)
+	if err != nil {
+		return nil, err
+	}
 
 	// Build replica.
 	r := litestream.NewReplica(db, c.Name, client)
@@ -257,6 +407,10 @@ func ParseReplicaURL(s string) (scheme, host, urlpath string, err error) {
 
 // newFileReplicaClientFromConfig returns a new instance of FileReplicaClient built from config.
 func newFileReplicaClientFromConfig(c *ReplicaConfig) (_ *litestream.FileReplicaClient, err error) {
+	if err := c.Options.Validate(replicaOptionKeys["file"]...); err != nil {
+		return nil, err
+	}
+
 	// Ensure URL & path are not both specified.
 	if c.URL != "" && c.Path != "" {
 		return nil, fmt.Errorf("cannot specify url & path for file replica")
@@ -286,6 +440,10 @@ func newFileReplicaClientFromConfig(c *ReplicaConfig) (_ *litestream.FileReplica
 
 // newS3ReplicaClientFromConfig returns a new instance of s3.ReplicaClient built from config.
 func newS3ReplicaClientFromConfig(c *ReplicaConfig) (_ *s3.ReplicaClient, err error) {
+	if err := c.Options.Validate(replicaOptionKeys["s3"]...); err != nil {
+		return nil, err
+	}
+
 	// Ensure URL & constituent parts are not both specified.
 	if c.URL != "" && c.Path != "" {
 		return nil, fmt.Errorf("cannot specify url & path for s3 replica")
@@ -334,10 +492,19 @@ func newS3ReplicaClientFromConfig(c *ReplicaConfig) (_ *s3.ReplicaClient, err er
 		return nil, fmt.Errorf("bucket required for s3 replica")
 	}
 
+	accessKeyID, err := resolveCredential(c.AccessKeyID)
+	if err != nil {
+		return nil, err
+	}
+	secretAccessKey, err := resolveCredential(c.SecretAccessKey)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build replica.
 	client := s3.NewReplicaClient()
-	client.AccessKeyID = c.AccessKeyID
-	client.SecretAccessKey = c.SecretAccessKey
+	client.AccessKeyID = accessKeyID
+	client.SecretAccessKey = secretAccessKey
 	client.Bucket = bucket
 	client.Path = path
 	client.Region = region
@@ -349,6 +516,10 @@ func newS3ReplicaClientFromConfig(c *ReplicaConfig) (_ *s3.ReplicaClient, err er
 
 // newGSReplicaClientFromConfig returns a new instance of gs.ReplicaClient built from config.
 func newGSReplicaClientFromConfig(c *ReplicaConfig) (_ *gs.ReplicaClient, err error) {
+	if err := c.Options.Validate(replicaOptionKeys["gs"]...); err != nil {
+		return nil, err
+	}
+
 	// Ensure URL & constituent parts are not both specified.
 	if c.URL != "" && c.Path != "" {
 		return nil, fmt.Errorf("cannot specify url & path for gs replica")
@@ -388,6 +559,10 @@ func newGSReplicaClientFromConfig(c *ReplicaConfig) (_ *gs.ReplicaClient, err er
 
 // newABSReplicaClientFromConfig returns a new instance of abs.ReplicaClient built from config.
 func newABSReplicaClientFromConfig(c *ReplicaConfig) (_ *abs.ReplicaClient, err error) {
+	if err := c.Options.Validate(replicaOptionKeys["abs"]...); err != nil {
+		return nil, err
+	}
+
 	// Ensure URL & constituent parts are not both specified.
 	if c.URL != "" && c.Path != "" {
 		return nil, fmt.Errorf("cannot specify url & path for abs replica")
@@ -395,13 +570,23 @@ func newABSReplicaClientFromConfig(c *ReplicaConfig) (_ *abs.ReplicaClient, err
 		return nil, fmt.Errorf("cannot specify url & bucket for abs replica")
 	}
 
+	accountKey, err := resolveCredential(c.AccountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := c.Endpoint
+	if suffix := c.Options.String("endpoint-suffix", ""); suffix != "" && endpoint != "" {
+		endpoint += suffix
+	}
+
 	// Build replica.
 	client := abs.NewReplicaClient()
 	client.AccountName = c.AccountName
-	client.AccountKey = c.AccountKey
+	client.AccountKey = accountKey
 	client.Bucket = c.Bucket
 	client.Path = c.Path
-	client.Endpoint = c.Endpoint
+	client.Endpoint = endpoint
 
 	// Apply settings from URL, if specified.
 	if c.URL != "" {
@@ -431,6 +616,10 @@ func newABSReplicaClientFromConfig(c *ReplicaConfig) (_ *abs.ReplicaClient, err
 
 // newSFTPReplicaClientFromConfig returns a new instance of sftp.ReplicaClient built from config.
 func newSFTPReplicaClientFromConfig(c *ReplicaConfig) (_ *sftp.ReplicaClient, err error) {
+	if err := c.Options.Validate(replicaOptionKeys["sftp"]...); err != nil {
+		return nil, err
+	}
+
 	// Ensure URL & constituent parts are not both specified.
 	if c.URL != "" && c.Path != "" {
 		return nil, fmt.Errorf("cannot specify url & path for sftp replica")
@@ -469,13 +658,21 @@ func newSFTPReplicaClientFromConfig(c *ReplicaConfig) (_ *sftp.ReplicaClient, er
 		return nil, fmt.Errorf("user required for sftp replica")
 	}
 
+	if password, err = resolveCredential(password); err != nil {
+		return nil, err
+	}
+	keyPath, err := resolveKeyPathCredential(c, c.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build replica.
 	client := sftp.NewReplicaClient()
 	client.Host = host
 	client.User = user
 	client.Password = password
 	client.Path = path
-	client.KeyPath = c.KeyPath
+	client.KeyPath = keyPath
 	return client, nil
 }
 
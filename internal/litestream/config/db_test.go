@@ -0,0 +1,60 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReplicaDefaultsForType(t *testing.T) {
+	retention := 24 * time.Hour
+	s3Retention := time.Hour
+
+	d := &ReplicaDefaults{
+		Retention: &retention,
+		Region:    "us-east-1",
+		Types: map[string]*ReplicaDefaults{
+			"s3": {
+				Retention: &s3Retention,
+				Endpoint:  "https://s3.example.com",
+			},
+			"gs": nil,
+		},
+	}
+
+	t.Run("unscoped type falls back to top-level defaults", func(t *testing.T) {
+		merged := d.forType("file")
+		if merged.Retention != &retention {
+			t.Errorf("Retention = %v, want %v", merged.Retention, &retention)
+		}
+		if merged.Region != "us-east-1" {
+			t.Errorf("Region = %q, want %q", merged.Region, "us-east-1")
+		}
+		if merged.Types != nil {
+			t.Errorf("Types = %v, want nil", merged.Types)
+		}
+	})
+
+	t.Run("per-type value overrides top-level value", func(t *testing.T) {
+		merged := d.forType("s3")
+		if merged.Retention != &s3Retention {
+			t.Errorf("Retention = %v, want %v", merged.Retention, &s3Retention)
+		}
+		if merged.Endpoint != "https://s3.example.com" {
+			t.Errorf("Endpoint = %q, want %q", merged.Endpoint, "https://s3.example.com")
+		}
+	})
+
+	t.Run("per-type value that does not set a field falls back to top-level", func(t *testing.T) {
+		merged := d.forType("s3")
+		if merged.Region != "us-east-1" {
+			t.Errorf("Region = %q, want %q", merged.Region, "us-east-1")
+		}
+	})
+
+	t.Run("nil entry in Types is treated the same as no entry", func(t *testing.T) {
+		merged := d.forType("gs")
+		if merged.Retention != &retention {
+			t.Errorf("Retention = %v, want %v", merged.Retention, &retention)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+// Package options implements a small key=value option set used by replica
+// configs to expose advanced, backend-specific tuning knobs (e.g. abs's
+// endpoint-suffix) without growing the YAML schema with a new field for
+// every knob a backend supports.
+package options
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options is a set of key=value options, typically parsed from repeated
+// `--replica-option key=value` CLI flags or a replica's `options` YAML map.
+type Options map[string]string
+
+// ParseFlag parses a single "key=value" CLI flag value and merges it into
+// o, creating the map if necessary. It is intended for use with a repeated
+// flag such as `--replica-option key=value`; no such flag is wired up to a
+// command, and config-file loading fills the map directly from YAML, so
+// this is reachable only from this package's tests and direct callers.
+func (o *Options) ParseFlag(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("invalid option %q, expected key=value", s)
+	}
+	if *o == nil {
+		*o = make(Options)
+	}
+	(*o)[parts[0]] = parts[1]
+	return nil
+}
+
+// String returns the value for key, or def if key is not set.
+func (o Options) String(key, def string) string {
+	if v, ok := o[key]; ok {
+		return v
+	}
+	return def
+}
+
+// Bool returns the value for key parsed as a bool, or def if key is not set.
+func (o Options) Bool(key string, def bool) (bool, error) {
+	v, ok := o[key]
+	if !ok {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("option %s: invalid bool %q", key, v)
+	}
+	return b, nil
+}
+
+// Int returns the value for key parsed as an int, or def if key is not set.
+func (o Options) Int(key string, def int) (int, error) {
+	v, ok := o[key]
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("option %s: invalid int %q", key, v)
+	}
+	return n, nil
+}
+
+// Duration returns the value for key parsed as a time.Duration, or def if
+// key is not set.
+func (o Options) Duration(key string, def time.Duration) (time.Duration, error) {
+	v, ok := o[key]
+	if !ok {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("option %s: invalid duration %q", key, v)
+	}
+	return d, nil
+}
+
+// Validate returns an error if o contains any key not present in allowed.
+// Backends call this with their own supported key list so that a typo or a
+// knob meant for a different backend fails config loading instead of being
+// silently ignored.
+func (o Options) Validate(allowed ...string) error {
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, k := range allowed {
+		allowedSet[k] = true
+	}
+	for k := range o {
+		if !allowedSet[k] {
+			return fmt.Errorf("unknown option: %q", k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package options
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOptionsParseFlag(t *testing.T) {
+	var o Options
+	if err := o.ParseFlag("endpoint-suffix=core.windows.net"); err != nil {
+		t.Fatalf("ParseFlag returned error: %v", err)
+	}
+	if got := o["endpoint-suffix"]; got != "core.windows.net" {
+		t.Errorf("o[%q] = %q, want %q", "endpoint-suffix", got, "core.windows.net")
+	}
+
+	if err := o.ParseFlag("no-equals-sign"); err == nil {
+		t.Error("ParseFlag with no '=' should have returned an error")
+	}
+	if err := o.ParseFlag("=value"); err == nil {
+		t.Error("ParseFlag with empty key should have returned an error")
+	}
+}
+
+func TestOptionsBool(t *testing.T) {
+	o := Options{"force": "true"}
+
+	if v, err := o.Bool("force", false); err != nil || !v {
+		t.Errorf("Bool(force) = (%v, %v), want (true, nil)", v, err)
+	}
+	if v, err := o.Bool("missing", true); err != nil || !v {
+		t.Errorf("Bool(missing) = (%v, %v), want (true, nil)", v, err)
+	}
+	if _, err := Options{"force": "yes"}.Bool("force", false); err == nil {
+		t.Error("Bool with invalid value should have returned an error")
+	}
+}
+
+func TestOptionsInt(t *testing.T) {
+	o := Options{"part-size": "5242880"}
+
+	if v, err := o.Int("part-size", 0); err != nil || v != 5242880 {
+		t.Errorf("Int(part-size) = (%v, %v), want (5242880, nil)", v, err)
+	}
+	if v, err := o.Int("missing", 42); err != nil || v != 42 {
+		t.Errorf("Int(missing) = (%v, %v), want (42, nil)", v, err)
+	}
+	if _, err := Options{"part-size": "big"}.Int("part-size", 0); err == nil {
+		t.Error("Int with invalid value should have returned an error")
+	}
+}
+
+func TestOptionsDuration(t *testing.T) {
+	o := Options{"timeout": "30s"}
+
+	if v, err := o.Duration("timeout", 0); err != nil || v != 30*time.Second {
+		t.Errorf("Duration(timeout) = (%v, %v), want (30s, nil)", v, err)
+	}
+	if v, err := o.Duration("missing", time.Minute); err != nil || v != time.Minute {
+		t.Errorf("Duration(missing) = (%v, %v), want (1m, nil)", v, err)
+	}
+	if _, err := Options{"timeout": "soon"}.Duration("timeout", 0); err == nil {
+		t.Error("Duration with invalid value should have returned an error")
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	if err := Options{"endpoint-suffix": "x"}.Validate("endpoint-suffix"); err != nil {
+		t.Errorf("Validate with allowed key returned error: %v", err)
+	}
+	if err := Options(nil).Validate("endpoint-suffix"); err != nil {
+		t.Errorf("Validate on nil Options returned error: %v", err)
+	}
+	if err := Options{"unknown": "x"}.Validate("endpoint-suffix"); err == nil {
+		t.Error("Validate with a key outside the allowed list should have returned an error")
+	}
+	if err := Options{"anything": "x"}.Validate(); err == nil {
+		t.Error("Validate with no allowed keys should reject every key")
+	}
+}
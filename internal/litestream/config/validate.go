@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// FormatValidationReport renders the result of Config.Validate as a
+// human-readable report, one line per entry of the aggregated error. An
+// entry for a DB, replica or backup destination with several issues wraps
+// a nested multierror, which is printed in multierror's own multi-line
+// format under that entry. It is meant for a `temporalite
+// litestream validate` subcommand to print. No such subcommand exists in
+// this tree yet (there is no cmd package to add it to); today this is only
+// called from NewServer's error path. It returns "configuration is valid"
+// if err is nil.
+func FormatValidationReport(err error) string {
+	if err == nil {
+		return "configuration is valid"
+	}
+
+	merr, ok := err.(*multierror.Error)
+	if !ok || len(merr.Errors) == 0 {
+		return fmt.Sprintf("configuration is invalid:\n  - %s", err)
+	}
+
+	report := fmt.Sprintf("configuration is invalid, found %d issue(s):\n", len(merr.Errors))
+	for _, e := range merr.Errors {
+		report += fmt.Sprintf("  - %s\n", e)
+	}
+	return report
+}
+
+// Validate checks the configuration for errors that `New*ReplicaClientFromConfig`
+// would otherwise only discover while constructing clients or opening
+// network connections, so that misconfiguration can be reported up front
+// as a single aggregated error.
+func (c *Config) Validate() error {
+	var result *multierror.Error
+
+	for i, dbc := range c.DBs {
+		if err := dbc.Validate(); err != nil {
+			result = multierror.Append(result, fmt.Errorf("dbs[%d] (%s): %w", i, dbc.Path, err))
+		}
+	}
+
+	if err := c.Backup.Validate(); err != nil {
+		result = multierror.Append(result, fmt.Errorf("backup: %w", err))
+	}
+
+	return result.ErrorOrNil()
+}
+
+// Validate checks the database configuration, including every replica
+// attached to it.
+func (c *DBConfig) Validate() error {
+	var result *multierror.Error
+
+	if c.Path == "" {
+		result = multierror.Append(result, fmt.Errorf("path required"))
+	} else if !filepath.IsAbs(c.Path) {
+		result = multierror.Append(result, fmt.Errorf("path must be absolute after expansion: %s", c.Path))
+	}
+
+	seen := make(map[string]bool, len(c.Replicas))
+	for i, rc := range c.Replicas {
+		if rc.Name != "" {
+			if seen[rc.Name] {
+				result = multierror.Append(result, fmt.Errorf("replicas[%d]: duplicate replica name: %q", i, rc.Name))
+			}
+			seen[rc.Name] = true
+		}
+
+		if err := rc.Validate(); err != nil {
+			result = multierror.Append(result, fmt.Errorf("replicas[%d] (%s): %w", i, rc.Name, err))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// Validate checks the replica configuration without constructing a client
+// or opening a network connection.
+func (c *ReplicaConfig) Validate() error {
+	var result *multierror.Error
+
+	typ := c.ReplicaType()
+
+	if isURL(c.Path) {
+		result = multierror.Append(result, fmt.Errorf("replica path cannot be a url, please use the 'url' field instead: %s", c.Path))
+	}
+
+	switch typ {
+	case "file":
+		if c.URL != "" && c.Path != "" {
+			result = multierror.Append(result, fmt.Errorf("cannot specify url & path for file replica"))
+		}
+		if c.URL == "" && c.Path == "" {
+			result = multierror.Append(result, fmt.Errorf("file replica path required"))
+		}
+	case "s3", "gs":
+		if c.URL != "" && c.Path != "" {
+			result = multierror.Append(result, fmt.Errorf("cannot specify url & path for %s replica", typ))
+		}
+		if c.URL != "" && c.Bucket != "" {
+			result = multierror.Append(result, fmt.Errorf("cannot specify url & bucket for %s replica", typ))
+		}
+		if c.URL == "" && c.Bucket == "" {
+			result = multierror.Append(result, fmt.Errorf("bucket required for %s replica", typ))
+		}
+	case "abs":
+		if c.URL != "" && c.Path != "" {
+			result = multierror.Append(result, fmt.Errorf("cannot specify url & path for abs replica"))
+		}
+		if c.URL != "" && c.Bucket != "" {
+			result = multierror.Append(result, fmt.Errorf("cannot specify url & bucket for abs replica"))
+		}
+		if c.URL == "" && c.Bucket == "" {
+			result = multierror.Append(result, fmt.Errorf("bucket required for abs replica"))
+		}
+	case "sftp":
+		if c.URL != "" && c.Path != "" {
+			result = multierror.Append(result, fmt.Errorf("cannot specify url & path for sftp replica"))
+		}
+		if c.URL != "" && c.Host != "" {
+			result = multierror.Append(result, fmt.Errorf("cannot specify url & host for sftp replica"))
+		}
+		if c.URL == "" && c.Host == "" {
+			result = multierror.Append(result, fmt.Errorf("host required for sftp replica"))
+		}
+		if c.URL == "" && c.User == "" {
+			result = multierror.Append(result, fmt.Errorf("user required for sftp replica"))
+		}
+	default:
+		result = multierror.Append(result, fmt.Errorf("unknown replica type in config: %q", typ))
+	}
+
+	if err := c.Options.Validate(replicaOptionKeys[typ]...); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	for _, ref := range []string{c.AccessKeyID, c.SecretAccessKey, c.AccountKey, c.Password, c.KeyPath} {
+		if ref == "" || !isURL(ref) {
+			continue
+		}
+		if err := checkCredentialRefReachable(ref); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	return result.ErrorOrNil()
+}
@@ -1,17 +1,30 @@
 package litestream
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/benbjohnson/litestream"
+	"github.com/fsnotify/fsnotify"
 
 	"github.com/temporalio/temporalite/internal/litestream/config"
 )
 
+const (
+	// waitForDBInitialBackoff is the initial delay between fallback polls
+	// for dbPath while waiting for the DB to be created.
+	waitForDBInitialBackoff = 100 * time.Millisecond
+
+	// waitForDBMaxBackoff caps the exponential backoff between fallback
+	// polls for dbPath.
+	waitForDBMaxBackoff = 30 * time.Second
+)
+
 type BackupServer interface {
-	Start() error
+	Start(ctx context.Context) error
 	Stop()
 }
 
@@ -19,9 +32,16 @@ type Server struct {
 	server *litestream.Server
 	dbPath string
 	config config.Config
+	backup *Backup
+
+	cancel context.CancelFunc
 }
 
-func NewServer(config config.Config, dbPath string) (*Server, error) {
+func NewServer(cfg config.Config, dbPath string) (*Server, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid litestream configuration: %s", config.FormatValidationReport(err))
+	}
+
 	liteServer := litestream.NewServer()
 	if err := liteServer.Open(); err != nil {
 		return nil, fmt.Errorf("open server: %w", err)
@@ -30,48 +50,130 @@ func NewServer(config config.Config, dbPath string) (*Server, error) {
 	server := &Server{
 		server: liteServer,
 		dbPath: dbPath,
-		config: config,
+		config: cfg,
+	}
+	if cfg.Backup != nil {
+		server.backup = NewBackup(cfg.Backup, dbPath)
 	}
 	return server, nil
 }
-func (s *Server) Start() error {
-	err := s.waitForDB()
-	if err != nil {
+
+// Start waits for the DB at s.dbPath to exist and begins replication, along
+// with the periodic backup configured via config.Backup, if any. It blocks
+// until the DB is ready, ctx is canceled, or ctx's deadline is exceeded.
+// Once replication is underway, canceling ctx stops it, the same as calling
+// Stop.
+func (s *Server) Start(ctx context.Context) error {
+	if err := s.waitForDB(ctx); err != nil {
 		return err
 	}
 
-	err = s.server.Watch(s.dbPath, func(path string) (*litestream.DB, error) {
-		// TODO[litestream]: Validate configuration.
+	if err := s.server.Watch(s.dbPath, func(path string) (*litestream.DB, error) {
 		return config.NewDBFromConfigWithPath(s.config.DBs[0], s.dbPath)
-	})
-	if err != nil {
+	}); err != nil {
 		return err
 	}
 
+	// Derive our own cancelable context so that ctx being canceled and
+	// Stop being called directly both unblock the goroutine below, rather
+	// than only the former.
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
+	if s.backup != nil {
+		if err := s.backup.Start(ctx); err != nil {
+			cancel()
+			return fmt.Errorf("start backup: %w", err)
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+		fmt.Println("Stopping litestream server...")
+		_ = s.server.Close()
+		for _, rc := range s.config.DBs[0].Replicas {
+			config.CleanupTempCredentialFiles(rc)
+		}
+	}()
+
 	return nil
 }
 
+// Stop cancels replication and the periodic backup, if any. It is
+// equivalent to canceling the ctx passed to Start.
 func (s *Server) Stop() {
-	fmt.Println("Stopping litestream server...")
-	_ = s.server.Close()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if s.backup != nil {
+		s.backup.Stop()
+	}
 }
 
-// TODO[litestream]: Check this code.
-func (s *Server) waitForDB() error {
-	n := 1
-	for n < 10 {
-		_, err := os.Stat(s.dbPath)
-
-		// check if error is "file not exists"
-		if os.IsNotExist(err) {
-			fmt.Printf("%v file does not exist\n", s.dbPath)
-			time.Sleep(1 * time.Second)
-		} else {
+// waitForDB blocks until s.dbPath exists, ctx is canceled, or ctx's
+// deadline is exceeded. It watches the parent directory of dbPath for a
+// CREATE event on the DB filename, falling back to exponential-backoff
+// polling in case the event is missed, e.g. on filesystems or mounts
+// without reliable notifications.
+func (s *Server) waitForDB(ctx context.Context) error {
+	if _, err := os.Stat(s.dbPath); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("stat %s: %w", s.dbPath, err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	dir := filepath.Dir(s.dbPath)
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("watch %s: %w", dir, err)
+	}
+
+	name := filepath.Base(s.dbPath)
+	backoff := waitForDBInitialBackoff
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	for {
+		// Check again in case the file appeared between the previous
+		// check and now, e.g. while the watcher was being set up.
+		if _, err := os.Stat(s.dbPath); err == nil {
 			return nil
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("stat %s: %w", s.dbPath, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %s: %w", s.dbPath, ctx.Err())
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return fmt.Errorf("watcher for %s closed unexpectedly", dir)
+			}
+			if event.Op&fsnotify.Create != 0 && filepath.Base(event.Name) == name {
+				return nil
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return fmt.Errorf("watcher for %s closed unexpectedly", dir)
+			}
+			return fmt.Errorf("watch %s: %w", dir, err)
+
+		case <-timer.C:
+			fmt.Printf("%s still does not exist, retrying in %s\n", s.dbPath, backoff)
+			backoff *= 2
+			if backoff > waitForDBMaxBackoff {
+				backoff = waitForDBMaxBackoff
+			}
+			timer.Reset(backoff)
 		}
-		n += 1
 	}
-	return nil
 }
 
 func NewNoopServer() noopServer {
@@ -80,7 +182,7 @@ func NewNoopServer() noopServer {
 
 type noopServer struct{}
 
-func (noopServer) Start() error {
+func (noopServer) Start(ctx context.Context) error {
 	return nil
 }
 